@@ -0,0 +1,76 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package capability
+
+import "encoding/json"
+
+// NOTE: lifubang/moby_sys#chunk0-5 asked for Caps, Mask and Diff methods
+// on the Capabilities interface (plus a top-level Equal and
+// MarshalJSON/UnmarshalJSON producing {"effective":[...],...} keyed by
+// CapType), wrapping a *capsV3-style implementation. This tree does not
+// contain the Capabilities interface or its implementation (no
+// capability.go/capability_linux.go checked in here), so those methods
+// cannot be added without inventing that type from scratch. What
+// follows is the CapType-agnostic bitmask/JSON plumbing those methods
+// would be built on; it is not a substitute for the interface methods
+// themselves, and this request is blocked on that interface landing.
+
+// CapDiff holds the capabilities added and removed between two
+// capability bitmasks of the same CapType.
+type CapDiff struct {
+	Added   []Cap
+	Removed []Cap
+}
+
+// MaskToCaps returns the capabilities set in mask, a 64-bit bitmask as
+// used e.g. by FileCaps.Permitted, in ascending order.
+func MaskToCaps(mask uint64) []Cap {
+	var caps []Cap
+	for i := Cap(0); i <= 63; i++ {
+		if mask&(uint64(1)<<uint(i)) != 0 {
+			caps = append(caps, i)
+		}
+	}
+	return caps
+}
+
+// CapsToMask returns the 64-bit bitmask corresponding to caps.
+func CapsToMask(caps []Cap) uint64 {
+	var mask uint64
+	for _, c := range caps {
+		mask |= uint64(1) << uint(c)
+	}
+	return mask
+}
+
+// DiffMask computes the CapDiff between the before and after bitmasks,
+// i.e. which capabilities were added and which were removed going from
+// before to after.
+func DiffMask(before, after uint64) CapDiff {
+	return CapDiff{
+		Added:   MaskToCaps(after &^ before),
+		Removed: MaskToCaps(before &^ after),
+	}
+}
+
+// MarshalCapsJSON returns caps as a JSON array of canonical names, e.g.
+// ["CAP_CHOWN","CAP_KILL"].
+func MarshalCapsJSON(caps []Cap) ([]byte, error) {
+	names := make([]string, len(caps))
+	for i, c := range caps {
+		names[i] = c.CanonicalString()
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalCapsJSON parses a JSON array of capability names, in any
+// form accepted by ParseCap, as produced by MarshalCapsJSON.
+func UnmarshalCapsJSON(data []byte) ([]Cap, error) {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, err
+	}
+	return List(names)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package capability_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/moby/sys/capability"
+)
+
+func TestMaskCapsRoundTrip(t *testing.T) {
+	want := []Cap{CAP_CHOWN, CAP_KILL, CAP_SYS_CHROOT}
+	mask := CapsToMask(want)
+	got := MaskToCaps(mask)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MaskToCaps(CapsToMask(%v)): want %v, got %v", want, want, got)
+	}
+}
+
+func TestDiffMask(t *testing.T) {
+	before := CapsToMask([]Cap{CAP_CHOWN, CAP_KILL})
+	after := CapsToMask([]Cap{CAP_KILL, CAP_SYS_CHROOT})
+
+	diff := DiffMask(before, after)
+	if want := []Cap{CAP_SYS_CHROOT}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("DiffMask.Added: want %v, got %v", want, diff.Added)
+	}
+	if want := []Cap{CAP_CHOWN}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("DiffMask.Removed: want %v, got %v", want, diff.Removed)
+	}
+}
+
+func TestDiffMaskNoChange(t *testing.T) {
+	mask := CapsToMask([]Cap{CAP_CHOWN, CAP_KILL})
+	diff := DiffMask(mask, mask)
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Errorf("DiffMask(x, x): want empty diff, got %+v", diff)
+	}
+}
+
+func TestMarshalCapsJSONRoundTrip(t *testing.T) {
+	want := []Cap{CAP_CHOWN, CAP_KILL, CAP_SYS_CHROOT}
+	data, err := MarshalCapsJSON(want)
+	if err != nil {
+		t.Fatalf("MarshalCapsJSON: want nil, got error: %v", err)
+	}
+	if g, w := string(data), `["CAP_CHOWN","CAP_KILL","CAP_SYS_CHROOT"]`; g != w {
+		t.Errorf("MarshalCapsJSON: want %s, got %s", w, g)
+	}
+
+	got, err := UnmarshalCapsJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalCapsJSON: want nil, got error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnmarshalCapsJSON: want %v, got %v", want, got)
+	}
+}
+
+func TestUnmarshalCapsJSONUnknown(t *testing.T) {
+	if _, err := UnmarshalCapsJSON([]byte(`["CAP_CHOWN","CAP_BOGUS"]`)); err == nil {
+		t.Error("UnmarshalCapsJSON: want error, got nil")
+	}
+}
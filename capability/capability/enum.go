@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type CapType uint
@@ -350,25 +351,63 @@ var (
 	capUpperMask = ^uint32(0)
 )
 
-func getLastCap() (Cap, error) {
-	str, err := ioutil.ReadFile("/proc/sys/kernel/cap_last_cap")
-	if err != nil {
-		return 0, err
-	}
-	val, err := strconv.Atoi(strings.TrimSpace(string(str)))
+// ProcfsPath sets the path to the procfs file system, used by LastCap
+// to read sys/kernel/cap_last_cap. LastCap is first called, and its
+// result cached, from this package's init function, i.e. before code in
+// an importing package can assign ProcfsPath; a value assigned later
+// has no effect on what LastCap returns.
+var ProcfsPath = "/proc"
+
+// LastCapErr holds the error, if any, that LastCap encountered when it
+// determined the highest valid capability of the running kernel. LastCap
+// does this once, from this package's init function.
+var LastCapErr error
+
+var lastCapOnce sync.Once
+
+// LastCap returns the highest valid capability of the running kernel,
+// i.e. CAP_LAST_CAP, caching the result behind a sync.Once.
+//
+// It first tries to read cap_last_cap from ProcfsPath; if that fails --
+// e.g. because the caller is running in a chroot or a sandbox without
+// /proc mounted -- it falls back to probing the kernel directly via
+// repeated PR_CAPBSET_READ prctl(2) calls.
+func LastCap() (Cap, error) {
+	lastCapOnce.Do(func() {
+		var lastCap Cap
+		lastCap, LastCapErr = getLastCap()
+		if LastCapErr == nil {
+			CAP_LAST_CAP = lastCap
+			if lastCap > 31 {
+				capUpperMask = (uint32(1) << (uint(lastCap) - 31)) - 1
+			} else {
+				capUpperMask = 0
+			}
+		}
+	})
+	return CAP_LAST_CAP, LastCapErr
+}
+
+// MustLastCap is like LastCap, but panics if the highest valid
+// capability of the running kernel cannot be determined.
+func MustLastCap() Cap {
+	c, err := LastCap()
 	if err != nil {
-		return 0, err
+		panic(err)
 	}
-	return Cap(val), nil
+	return c
 }
 
-func init() {
-	if lastCap, err := getLastCap(); err == nil {
-		CAP_LAST_CAP = lastCap
-		if lastCap > 31 {
-			capUpperMask = (uint32(1) << (uint(lastCap) - 31)) - 1
-		} else {
-			capUpperMask = 0
+func getLastCap() (Cap, error) {
+	str, err := ioutil.ReadFile(ProcfsPath + "/sys/kernel/cap_last_cap")
+	if err == nil {
+		if val, err := strconv.Atoi(strings.TrimSpace(string(str))); err == nil {
+			return Cap(val), nil
 		}
 	}
+	return probeLastCap()
+}
+
+func init() {
+	_, _ = LastCap()
 }
@@ -0,0 +1,218 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package capability
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrNameCaps is the name of the extended attribute used by the
+// kernel to store file capabilities. See capabilities(7).
+const xattrNameCaps = "security.capability"
+
+// vfs_cap_data revisions and flags, as defined in
+// linux/capability.h.
+const (
+	vfsCapRevision1 = 0x01000000
+	vfsCapRevision2 = 0x02000000
+	vfsCapRevision3 = 0x03000000
+
+	vfsCapRevisionMask  = 0xFF000000
+	vfsCapFlagEffective = 0x000001
+
+	vfsCapDataSizeV1 = 4 + 4 + 4            // magic_etc + permitted[0] + inheritable[0]
+	vfsCapDataSizeV2 = 4 + 2*(4+4)          // magic_etc + {permitted,inheritable}[2]
+	vfsCapDataSizeV3 = vfsCapDataSizeV2 + 4 // + rootid
+)
+
+// ErrNoFileCaps is returned by GetFile when path has no
+// security.capability extended attribute set.
+var ErrNoFileCaps = errors.New("capability: no file capabilities set")
+
+// FileCaps represents the capability sets stored in a file's
+// security.capability extended attribute.
+//
+// Unlike process capabilities, a file only carries a Permitted and an
+// Inheritable set, plus a single Effective flag: when true, it tells
+// the kernel to raise the whole Permitted set into the process'
+// Effective set across execve(2).
+type FileCaps struct {
+	Permitted   uint64
+	Inheritable uint64
+	Effective   bool
+
+	// RootID is the namespaced root uid a v3 (VFS_CAP_REVISION_3) file
+	// capability is relative to. A zero value means the capability is
+	// a v2 (non-namespaced) one.
+	RootID uint32
+}
+
+// GetFile returns the file capabilities stored in path's
+// security.capability extended attribute. It returns ErrNoFileCaps if
+// path has none set. If path is a symlink, it is dereferenced; use
+// GetFileL to operate on the symlink itself.
+func GetFile(path string) (*FileCaps, error) {
+	buf, err := getxattr(path, unix.Getxattr)
+	if err != nil {
+		return nil, err
+	}
+	return parseFileCaps(buf)
+}
+
+// GetFileL is like GetFile, but does not dereference path if it is a
+// symlink.
+func GetFileL(path string) (*FileCaps, error) {
+	buf, err := getxattr(path, unix.Lgetxattr)
+	if err != nil {
+		return nil, err
+	}
+	return parseFileCaps(buf)
+}
+
+// SetFile sets path's security.capability extended attribute to fc. It
+// writes a v3 (namespaced) capability when fc.RootID is non-zero, and a
+// v2 one otherwise. Use SetFileV1 to request the legacy 32-bit v1
+// encoding. If path is a symlink, it is dereferenced; use SetFileL to
+// operate on the symlink itself.
+func SetFile(path string, fc *FileCaps) error {
+	return setFile(path, fc, false, unix.Setxattr)
+}
+
+// SetFileL is like SetFile, but does not dereference path if it is a
+// symlink.
+func SetFileL(path string, fc *FileCaps) error {
+	return setFile(path, fc, false, unix.Lsetxattr)
+}
+
+// SetFileV1 is like SetFile, but always writes the legacy 32-bit
+// VFS_CAP_REVISION_1 encoding. It fails if fc has a non-zero RootID, or
+// any bit above bit 31 set in Permitted or Inheritable.
+func SetFileV1(path string, fc *FileCaps) error {
+	return setFile(path, fc, true, unix.Setxattr)
+}
+
+// SetFileV1L is like SetFileV1, but does not dereference path if it is
+// a symlink.
+func SetFileV1L(path string, fc *FileCaps) error {
+	return setFile(path, fc, true, unix.Lsetxattr)
+}
+
+// RemoveFile removes the security.capability extended attribute from
+// path, if any.
+func RemoveFile(path string) error {
+	err := unix.Removexattr(path, xattrNameCaps)
+	if errors.Is(err, unix.ENODATA) {
+		return nil
+	}
+	return err
+}
+
+func setFile(path string, fc *FileCaps, v1 bool, setxattr func(path, attr string, data []byte, flags int) error) error {
+	buf, err := fc.marshal(v1)
+	if err != nil {
+		return fmt.Errorf("capability: %s: %w", path, err)
+	}
+	return setxattr(path, xattrNameCaps, buf, 0)
+}
+
+func getxattr(path string, getxattr func(path, attr string, dest []byte) (int, error)) ([]byte, error) {
+	// A v3 vfs_cap_data is the largest encoding; start there and grow
+	// on ERANGE in case a future revision is bigger.
+	size := vfsCapDataSizeV3
+	for {
+		buf := make([]byte, size)
+		n, err := getxattr(path, xattrNameCaps, buf)
+		if err != nil {
+			if errors.Is(err, unix.ERANGE) {
+				size *= 2
+				continue
+			}
+			if errors.Is(err, unix.ENODATA) {
+				return nil, ErrNoFileCaps
+			}
+			return nil, fmt.Errorf("capability: %s: %w", path, err)
+		}
+		return buf[:n], nil
+	}
+}
+
+func parseFileCaps(buf []byte) (*FileCaps, error) {
+	if len(buf) < vfsCapDataSizeV1 {
+		return nil, fmt.Errorf("capability: short vfs_cap_data (%d bytes)", len(buf))
+	}
+	magic := binary.LittleEndian.Uint32(buf[0:4])
+	fc := &FileCaps{Effective: magic&vfsCapFlagEffective != 0}
+
+	permLow := binary.LittleEndian.Uint32(buf[4:8])
+	inhLow := binary.LittleEndian.Uint32(buf[8:12])
+
+	switch magic &^ vfsCapFlagEffective {
+	case vfsCapRevision1:
+		fc.Permitted = uint64(permLow)
+		fc.Inheritable = uint64(inhLow)
+		return fc, nil
+	case vfsCapRevision2, vfsCapRevision3:
+		if len(buf) < vfsCapDataSizeV2 {
+			return nil, fmt.Errorf("capability: short vfs_cap_data (%d bytes)", len(buf))
+		}
+		permHigh := binary.LittleEndian.Uint32(buf[12:16])
+		inhHigh := binary.LittleEndian.Uint32(buf[16:20])
+		fc.Permitted = uint64(permLow) | uint64(permHigh)<<32
+		fc.Inheritable = uint64(inhLow) | uint64(inhHigh)<<32
+		if magic&^vfsCapFlagEffective == vfsCapRevision3 {
+			if len(buf) < vfsCapDataSizeV3 {
+				return nil, fmt.Errorf("capability: short vfs_cap_data (%d bytes)", len(buf))
+			}
+			fc.RootID = binary.LittleEndian.Uint32(buf[20:24])
+		}
+		return fc, nil
+	default:
+		return nil, fmt.Errorf("capability: unsupported vfs_cap_data revision %#x", magic&vfsCapRevisionMask)
+	}
+}
+
+func (fc *FileCaps) marshal(v1 bool) ([]byte, error) {
+	var effective uint32
+	if fc.Effective {
+		effective = vfsCapFlagEffective
+	}
+
+	if v1 {
+		if fc.RootID != 0 {
+			return nil, errors.New("cannot encode as v1: RootID is set")
+		}
+		if fc.Permitted > 0xFFFFFFFF || fc.Inheritable > 0xFFFFFFFF {
+			return nil, errors.New("cannot encode as v1: capabilities above bit 31 are set")
+		}
+		buf := make([]byte, vfsCapDataSizeV1)
+		binary.LittleEndian.PutUint32(buf[0:4], vfsCapRevision1|effective)
+		binary.LittleEndian.PutUint32(buf[4:8], uint32(fc.Permitted))
+		binary.LittleEndian.PutUint32(buf[8:12], uint32(fc.Inheritable))
+		return buf, nil
+	}
+
+	rev := uint32(vfsCapRevision2)
+	size := vfsCapDataSizeV2
+	if fc.RootID != 0 {
+		rev = vfsCapRevision3
+		size = vfsCapDataSizeV3
+	}
+	buf := make([]byte, size)
+	binary.LittleEndian.PutUint32(buf[0:4], rev|effective)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(fc.Permitted))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(fc.Inheritable))
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(fc.Permitted>>32))
+	binary.LittleEndian.PutUint32(buf[16:20], uint32(fc.Inheritable>>32))
+	if rev == vfsCapRevision3 {
+		binary.LittleEndian.PutUint32(buf[20:24], fc.RootID)
+	}
+	return buf, nil
+}
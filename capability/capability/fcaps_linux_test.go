@@ -0,0 +1,177 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package capability
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFileCapsRoundTrip(t *testing.T) {
+	for name, fc := range map[string]*FileCaps{
+		"v2": {
+			Permitted:   1<<CAP_CHOWN | 1<<CAP_KILL,
+			Inheritable: 1 << CAP_SYS_CHROOT,
+			Effective:   true,
+		},
+		"v2-high-bits": {
+			Permitted:   1 << 40,
+			Inheritable: 1<<63 | 1,
+		},
+		"v3": {
+			Permitted: 1 << CAP_CHOWN,
+			RootID:    1000,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf, err := fc.marshal(false)
+			if err != nil {
+				t.Fatalf("marshal: want nil, got error: %v", err)
+			}
+			got, err := parseFileCaps(buf)
+			if err != nil {
+				t.Fatalf("parseFileCaps: want nil, got error: %v", err)
+			}
+			if *got != *fc {
+				t.Errorf("round trip: want %+v, got %+v", fc, got)
+			}
+		})
+	}
+}
+
+func TestFileCapsRoundTripV1(t *testing.T) {
+	fc := &FileCaps{
+		Permitted:   1<<CAP_CHOWN | 1<<CAP_KILL,
+		Inheritable: 1 << CAP_SYS_CHROOT,
+		Effective:   true,
+	}
+	buf, err := fc.marshal(true)
+	if err != nil {
+		t.Fatalf("marshal: want nil, got error: %v", err)
+	}
+	if len(buf) != vfsCapDataSizeV1 {
+		t.Fatalf("marshal(v1): want %d bytes, got %d", vfsCapDataSizeV1, len(buf))
+	}
+	got, err := parseFileCaps(buf)
+	if err != nil {
+		t.Fatalf("parseFileCaps: want nil, got error: %v", err)
+	}
+	if *got != *fc {
+		t.Errorf("round trip: want %+v, got %+v", fc, got)
+	}
+}
+
+func TestFileCapsMarshalV1Rejects(t *testing.T) {
+	for name, fc := range map[string]*FileCaps{
+		"rootid-set":         {RootID: 1},
+		"permitted-above-31": {Permitted: 1 << 32},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := fc.marshal(true); err == nil {
+				t.Error("marshal(v1): want error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseFileCapsErrors(t *testing.T) {
+	for name, buf := range map[string][]byte{
+		"empty":            {},
+		"short":            {0, 0, 0},
+		"unknown-revision": {0, 0, 0, 0xff, 0, 0, 0, 0, 0, 0, 0, 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseFileCaps(buf); err == nil {
+				t.Error("parseFileCaps: want error, got nil")
+			}
+		})
+	}
+}
+
+// TestGetFileNoXattr exercises getxattr's real unix.Getxattr call (as
+// opposed to the in-memory marshal/parseFileCaps tests above) against a
+// temp file that has no security.capability xattr set, and checks the
+// ENODATA-to-ErrNoFileCaps translation.
+func TestGetFileNoXattr(t *testing.T) {
+	f, err := os.CreateTemp("", "fcaps-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	if _, err := GetFile(name); !errors.Is(err, ErrNoFileCaps) {
+		t.Errorf("GetFile(no xattr): want ErrNoFileCaps, got %v", err)
+	}
+}
+
+// TestRemoveFileNoXattr exercises RemoveFile's real unix.Removexattr
+// call against a temp file that has no security.capability xattr set,
+// and checks that it is a no-op rather than an error.
+func TestRemoveFileNoXattr(t *testing.T) {
+	f, err := os.CreateTemp("", "fcaps-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	err = RemoveFile(name)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		t.Skipf("extended attributes not supported on %s: %v", os.TempDir(), err)
+	}
+	if err != nil {
+		t.Errorf("RemoveFile(no xattr): want nil, got %v", err)
+	}
+}
+
+// TestGetxattrERANGE exercises getxattr's ERANGE buffer-growth loop
+// with a stub that forces two rounds of growth before succeeding, which
+// a real xattr would only do if the kernel reported a bigger value on a
+// later call (e.g. a concurrent writer).
+func TestGetxattrERANGE(t *testing.T) {
+	const want = "security.capability"
+	calls := 0
+	stub := func(path, attr string, dest []byte) (int, error) {
+		calls++
+		if attr != want {
+			t.Errorf("attr: want %q, got %q", want, attr)
+		}
+		if len(dest) < vfsCapDataSizeV3*4 {
+			return 0, unix.ERANGE
+		}
+		return copy(dest, []byte{1, 2, 3, 4}), nil
+	}
+	buf, err := getxattr("/some/path", stub)
+	if err != nil {
+		t.Fatalf("getxattr: want nil, got error: %v", err)
+	}
+	if calls < 2 {
+		t.Errorf("getxattr: want at least 2 calls to grow the buffer, got %d", calls)
+	}
+	if string(buf) != "\x01\x02\x03\x04" {
+		t.Errorf("getxattr: want [1 2 3 4], got %v", buf)
+	}
+}
+
+func TestParseFileCapsTruncatedV3(t *testing.T) {
+	fc := &FileCaps{Permitted: 1 << CAP_CHOWN, RootID: 1000}
+	buf, err := fc.marshal(false)
+	if err != nil {
+		t.Fatalf("marshal: want nil, got error: %v", err)
+	}
+	// Drop the trailing rootid word: the revision still says v3, but the
+	// buffer is now too short to contain it.
+	if _, err := parseFileCaps(buf[:vfsCapDataSizeV2]); err == nil {
+		t.Error("parseFileCaps: want error on truncated v3 buffer, got nil")
+	}
+}
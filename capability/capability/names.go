@@ -0,0 +1,94 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package capability
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AllCaps is the sentinel name accepted by ExpandAll to mean "every
+// capability supported by the running kernel".
+const AllCaps = "ALL"
+
+// capByName maps the lower-cased short name (as returned by
+// Cap.String) and the lower-cased canonical "cap_"-prefixed name to the
+// corresponding Cap.
+var capByName = map[string]Cap{}
+
+func init() {
+	for c := Cap(0); c <= CAP_AUDIT_READ; c++ {
+		name := c.String()
+		if name == "unknown" {
+			continue
+		}
+		capByName[name] = c
+		capByName["cap_"+name] = c
+	}
+}
+
+// CanonicalString returns the capability's canonical kernel name, e.g.
+// "CAP_CHOWN". It returns "unknown" for a Cap this package does not
+// know the name of.
+func (c Cap) CanonicalString() string {
+	name := c.String()
+	if name == "unknown" {
+		return name
+	}
+	return "CAP_" + strings.ToUpper(name)
+}
+
+// ParseCap parses name as a capability. Both the short form returned by
+// Cap.String (e.g. "chown") and the canonical kernel form (e.g.
+// "CAP_CHOWN"), in any case, are accepted.
+func ParseCap(name string) (Cap, error) {
+	if c, ok := capByName[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+	return 0, fmt.Errorf("capability: unknown capability %q", name)
+}
+
+// List parses names, each of which must be in a form accepted by
+// ParseCap, and returns the corresponding Caps.
+func List(names []string) ([]Cap, error) {
+	caps := make([]Cap, 0, len(names))
+	for _, name := range names {
+		c, err := ParseCap(name)
+		if err != nil {
+			return nil, err
+		}
+		caps = append(caps, c)
+	}
+	return caps, nil
+}
+
+// ListSupported returns every capability this package knows the name
+// of that is also supported by the running kernel, in ascending order.
+func ListSupported() ([]Cap, error) {
+	last, err := LastCap()
+	if err != nil {
+		return nil, err
+	}
+	caps := make([]Cap, 0, last+1)
+	for c := Cap(0); c <= last; c++ {
+		if c.String() != "unknown" {
+			caps = append(caps, c)
+		}
+	}
+	return caps, nil
+}
+
+// ExpandAll is like List, except that AllCaps (case-insensitive) is
+// expanded to ListSupported, i.e. every capability supported by the
+// running kernel. When AllCaps is present, the result is ListSupported
+// alone and the other names are not parsed.
+func ExpandAll(names []string) ([]Cap, error) {
+	for _, name := range names {
+		if strings.EqualFold(name, AllCaps) {
+			return ListSupported()
+		}
+	}
+	return List(names)
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package capability_test
+
+import (
+	"runtime"
+	"testing"
+
+	. "github.com/moby/sys/capability"
+)
+
+func TestParseCap(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want Cap
+	}{
+		{"chown", CAP_CHOWN},
+		{"CHOWN", CAP_CHOWN},
+		{"cap_chown", CAP_CHOWN},
+		{"CAP_CHOWN", CAP_CHOWN},
+		{"Cap_Chown", CAP_CHOWN},
+		{"sys_chroot", CAP_SYS_CHROOT},
+		{"CAP_SYS_CHROOT", CAP_SYS_CHROOT},
+	} {
+		got, err := ParseCap(tc.name)
+		if err != nil {
+			t.Errorf("ParseCap(%q): want nil, got error: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseCap(%q): want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestParseCapUnknown(t *testing.T) {
+	for _, name := range []string{"", "bogus", "cap_bogus", "chown "} {
+		if _, err := ParseCap(name); err == nil {
+			t.Errorf("ParseCap(%q): want error, got nil", name)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	got, err := List([]string{"chown", "CAP_KILL", "Sys_Chroot"})
+	if err != nil {
+		t.Fatalf("List: want nil, got error: %v", err)
+	}
+	want := []Cap{CAP_CHOWN, CAP_KILL, CAP_SYS_CHROOT}
+	if len(got) != len(want) {
+		t.Fatalf("List: want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("List[%d]: want %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestListUnknown(t *testing.T) {
+	if _, err := List([]string{"chown", "bogus"}); err == nil {
+		t.Error("List: want error, got nil")
+	}
+}
+
+func TestExpandAllNoSentinel(t *testing.T) {
+	// Without the AllCaps sentinel, ExpandAll behaves like List and does
+	// not need to probe the running kernel.
+	got, err := ExpandAll([]string{"chown", "kill"})
+	if err != nil {
+		t.Fatalf("ExpandAll: want nil, got error: %v", err)
+	}
+	want := []Cap{CAP_CHOWN, CAP_KILL}
+	if len(got) != len(want) {
+		t.Fatalf("ExpandAll: want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ExpandAll[%d]: want %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestExpandAllSentinel(t *testing.T) {
+	got, err := ExpandAll([]string{"chown", "ALL", "kill"})
+	switch runtime.GOOS {
+	default:
+		if err == nil {
+			t.Fatal(runtime.GOOS, ": want error, got nil")
+		}
+		return
+	case "linux":
+	}
+	if err != nil {
+		t.Fatalf("ExpandAll: want nil, got error: %v", err)
+	}
+	want, err := ListSupported()
+	if err != nil {
+		t.Fatalf("ListSupported: want nil, got error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ExpandAll(ALL): want %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ExpandAll(ALL)[%d]: want %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestCanonicalString(t *testing.T) {
+	for _, tc := range []struct {
+		c    Cap
+		want string
+	}{
+		{CAP_CHOWN, "CAP_CHOWN"},
+		{CAP_SYS_CHROOT, "CAP_SYS_CHROOT"},
+		{Cap(-1), "unknown"},
+	} {
+		if got := tc.c.CanonicalString(); got != tc.want {
+			t.Errorf("Cap(%d).CanonicalString(): want %q, got %q", tc.c, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package capability
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// lastKnownCap is the highest capability this package knows the name
+// of. It is the starting point for probeLastCap's PR_CAPBSET_READ
+// search.
+const lastKnownCap = CAP_AUDIT_READ
+
+// probeLastCap determines CAP_LAST_CAP by calling
+// prctl(PR_CAPBSET_READ, i, ...) starting at lastKnownCap. If that call
+// succeeds, i is increased until the syscall fails; if it fails with
+// EINVAL, i is decreased until the syscall succeeds. It is used as a
+// fallback when /proc/sys/kernel/cap_last_cap cannot be read.
+func probeLastCap() (Cap, error) {
+	i := int(lastKnownCap)
+	if _, err := unix.PrctlRetInt(unix.PR_CAPBSET_READ, uintptr(i), 0, 0, 0); err != nil {
+		if !errors.Is(err, unix.EINVAL) {
+			return 0, err
+		}
+		for i--; i >= 0; i-- {
+			if _, err := unix.PrctlRetInt(unix.PR_CAPBSET_READ, uintptr(i), 0, 0, 0); err == nil {
+				return Cap(i), nil
+			}
+		}
+		return 0, errors.New("capability: PR_CAPBSET_READ not supported by this kernel")
+	}
+
+	for {
+		i++
+		if _, err := unix.PrctlRetInt(unix.PR_CAPBSET_READ, uintptr(i), 0, 0, 0); err != nil {
+			return Cap(i - 1), nil
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package capability
+
+import (
+	"errors"
+	"runtime"
+)
+
+func probeLastCap() (Cap, error) {
+	return 0, errors.New("capability: not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 The Capability Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+package capability
+
+import (
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Secbits is a bitmask of securebits(7) flags, as read and set via
+// prctl(PR_GET_SECUREBITS) / prctl(PR_SET_SECUREBITS).
+type Secbits uint32
+
+const (
+	SECBIT_NOROOT                      Secbits = 1 << 0
+	SECBIT_NOROOT_LOCKED               Secbits = 1 << 1
+	SECBIT_NO_SETUID_FIXUP             Secbits = 1 << 2
+	SECBIT_NO_SETUID_FIXUP_LOCKED      Secbits = 1 << 3
+	SECBIT_KEEP_CAPS                   Secbits = 1 << 4
+	SECBIT_KEEP_CAPS_LOCKED            Secbits = 1 << 5
+	SECBIT_NO_CAP_AMBIENT_RAISE        Secbits = 1 << 6
+	SECBIT_NO_CAP_AMBIENT_RAISE_LOCKED Secbits = 1 << 7
+)
+
+var secbitNames = [...]struct {
+	bit  Secbits
+	name string
+}{
+	{SECBIT_NOROOT, "SECBIT_NOROOT"},
+	{SECBIT_NOROOT_LOCKED, "SECBIT_NOROOT_LOCKED"},
+	{SECBIT_NO_SETUID_FIXUP, "SECBIT_NO_SETUID_FIXUP"},
+	{SECBIT_NO_SETUID_FIXUP_LOCKED, "SECBIT_NO_SETUID_FIXUP_LOCKED"},
+	{SECBIT_KEEP_CAPS, "SECBIT_KEEP_CAPS"},
+	{SECBIT_KEEP_CAPS_LOCKED, "SECBIT_KEEP_CAPS_LOCKED"},
+	{SECBIT_NO_CAP_AMBIENT_RAISE, "SECBIT_NO_CAP_AMBIENT_RAISE"},
+	{SECBIT_NO_CAP_AMBIENT_RAISE_LOCKED, "SECBIT_NO_CAP_AMBIENT_RAISE_LOCKED"},
+}
+
+// String returns a "|"-separated list of s's set bits' names, e.g.
+// "SECBIT_NOROOT|SECBIT_KEEP_CAPS".
+func (s Secbits) String() string {
+	if s == 0 {
+		return "0"
+	}
+	var names []string
+	for _, sb := range secbitNames {
+		if s&sb.bit != 0 {
+			names = append(names, sb.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// GetSecbits returns the calling thread's current securebits.
+func GetSecbits() (Secbits, error) {
+	bits, err := unix.PrctlRetInt(unix.PR_GET_SECUREBITS, 0, 0, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	return Secbits(bits), nil
+}
+
+// SetSecbits sets the calling thread's securebits to s.
+func SetSecbits(s Secbits) error {
+	return unix.Prctl(unix.PR_SET_SECUREBITS, uintptr(s), 0, 0, 0)
+}
+
+// KeepCapsAcrossUIDChange runs fn with SECBIT_KEEP_CAPS set, restoring
+// the original securebits once fn returns. This is the prctl dance
+// needed to retain a curated set of capabilities in the permitted set
+// across a setresuid(2) call that drops privileges to an unprivileged
+// uid: set SECBIT_KEEP_CAPS, change uid inside fn, then re-raise the
+// desired capabilities from permitted into effective.
+//
+// Securebits are per-OS-thread state, so this locks the calling
+// goroutine to its current OS thread for the duration of the call --
+// otherwise the Go scheduler could run fn, or the deferred restore, on
+// a different thread than the one SECBIT_KEEP_CAPS was set on.
+func KeepCapsAcrossUIDChange(fn func() error) (err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	orig, err := GetSecbits()
+	if err != nil {
+		return err
+	}
+	if err := SetSecbits(orig | SECBIT_KEEP_CAPS); err != nil {
+		return err
+	}
+	defer func() {
+		if restoreErr := SetSecbits(orig); err == nil {
+			err = restoreErr
+		}
+	}()
+
+	return fn()
+}
@@ -14,6 +14,17 @@ import (
 	. "github.com/moby/sys/capability"
 )
 
+// Known-broken in this tree: this file predates, and is untouched by,
+// lifubang/moby_sys#chunk0-1..5. It references CAP_CHECKPOINT_RESTORE
+// (not defined in this tree's enum.go, which only goes up to
+// CAP_AUDIT_READ), as well as NewPid2 and AMBIENT, neither of which
+// exist here because the Capabilities interface and its process-caps
+// implementation (capability.go/capability_linux.go upstream) were
+// never part of this chunk. It does not compile as-is and none of the
+// five requests' fixes touch it; it also sits one directory above the
+// capability/capability/go.mod module root added for chunk0-1, so it
+// is not part of that module's build either.
+//
 // Based on the fact Go 1.18+ supports Linux >= 2.6.32, and
 //   - CAP_MAC_ADMIN (33) was added in 2.6.25;
 //   - CAP_SYSLOG (34) was added in 2.6.38;